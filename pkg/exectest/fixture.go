@@ -0,0 +1,221 @@
+package exectest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/chart"
+
+	"github.com/helmfile/helmfile/pkg/helmexec"
+)
+
+// Call is a single recorded interaction with a real helm binary: the method
+// and its string-ified arguments/flags, plus the stdout and error it
+// produced.
+type Call struct {
+	Method string   `json:"method"`
+	Args   []string `json:"args"`
+	Stdout string   `json:"stdout,omitempty"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// Fixture is a golden recording of every helmexec.Interface call made during
+// a run, loaded by ReplayHelm to satisfy the interface without a live helm or
+// cluster.
+type Fixture struct {
+	Calls []Call `json:"calls"`
+}
+
+// LoadFixture reads a Fixture previously written by SaveFixture.
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading fixture %s", path)
+	}
+
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, errors.Wrapf(err, "parsing fixture %s", path)
+	}
+
+	return &f, nil
+}
+
+// SaveFixture writes f to path as indented JSON, preserving Calls in the
+// order they were recorded so diffs between fixture revisions are meaningful
+// in review.
+func SaveFixture(path string, f *Fixture) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "marshaling fixture")
+	}
+
+	return errors.Wrapf(os.WriteFile(path, data, 0o644), "writing fixture %s", path)
+}
+
+// ReplayHelm satisfies helmexec.Interface by consuming a Fixture, failing any
+// call that doesn't match an unconsumed recorded one. It extends the
+// FailOnUnexpectedDiff/FailOnUnexpectedList idea already on Helm to a whole
+// recorded scenario, so a one-time capture of a real helm run can become a
+// regression test without a live cluster.
+//
+// Matching is by content (method + args) rather than position: Helm's own
+// DiffMutex/ChartsMutex/etc. exist so releases can be processed concurrently,
+// so a fixture recorded from a concurrent run has no stable call order.
+//
+// It embeds Helm and overrides every method that represents an actual helm
+// invocation. The setters (SetExtraArgs, SetHelmBinary, ...) and version
+// queries (IsHelm3, GetVersion, IsVersionAtLeast) are local configuration
+// and metadata rather than recorded calls, so they fall through to Helm's
+// own fake behavior.
+type ReplayHelm struct {
+	Helm
+
+	fixture *Fixture
+
+	mu       sync.Mutex
+	consumed []bool
+}
+
+// NewReplayHelm returns a ReplayHelm that satisfies helmexec.Interface by
+// replaying f.
+func NewReplayHelm(f *Fixture) *ReplayHelm {
+	return &ReplayHelm{fixture: f, consumed: make([]bool, len(f.Calls))}
+}
+
+// next consumes the first unconsumed fixture call whose method and args
+// match, failing if none does.
+func (r *ReplayHelm) next(method string, args ...string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, call := range r.fixture.Calls {
+		if r.consumed[i] || call.Method != method || !argsEqual(call.Args, args) {
+			continue
+		}
+
+		r.consumed[i] = true
+		if call.Error != "" {
+			return call.Stdout, errors.New(call.Error)
+		}
+		return call.Stdout, nil
+	}
+
+	return "", errors.Errorf("unexpected call to %s%v: no unconsumed fixture entry matches", method, args)
+}
+
+func argsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *ReplayHelm) SyncRelease(context helmexec.HelmContext, name, chart string, flags ...string) error {
+	_, err := r.next("SyncRelease", append([]string{name, chart}, flags...)...)
+	return err
+}
+
+func (r *ReplayHelm) DiffRelease(context helmexec.HelmContext, name, chart string, suppressDiff bool, flags ...string) error {
+	_, err := r.next("DiffRelease", append([]string{name, chart, fmt.Sprintf("%v", suppressDiff)}, flags...)...)
+	return err
+}
+
+func (r *ReplayHelm) DeleteRelease(context helmexec.HelmContext, name string, flags ...string) error {
+	_, err := r.next("DeleteRelease", append([]string{name}, flags...)...)
+	return err
+}
+
+func (r *ReplayHelm) Lint(name, chart string, flags ...string) error {
+	_, err := r.next("Lint", append([]string{name, chart}, flags...)...)
+	return err
+}
+
+func (r *ReplayHelm) TemplateRelease(name, chart string, flags ...string) error {
+	_, err := r.next("TemplateRelease", append([]string{name, chart}, flags...)...)
+	return err
+}
+
+func (r *ReplayHelm) TestRelease(context helmexec.HelmContext, name string, flags ...string) error {
+	_, err := r.next("TestRelease", append([]string{name}, flags...)...)
+	return err
+}
+
+func (r *ReplayHelm) BuildDeps(name, chart string, flags ...string) error {
+	_, err := r.next("BuildDeps", append([]string{name, chart}, flags...)...)
+	return err
+}
+
+func (r *ReplayHelm) UpdateDeps(chart string) error {
+	_, err := r.next("UpdateDeps", chart)
+	return err
+}
+
+func (r *ReplayHelm) List(context helmexec.HelmContext, filter string, flags ...string) (string, error) {
+	return r.next("List", append([]string{filter}, flags...)...)
+}
+
+func (r *ReplayHelm) ReleaseStatus(context helmexec.HelmContext, release string, flags ...string) error {
+	_, err := r.next("ReleaseStatus", append([]string{release}, flags...)...)
+	return err
+}
+
+func (r *ReplayHelm) DecryptSecret(context helmexec.HelmContext, name string, flags ...string) (string, error) {
+	return r.next("DecryptSecret", append([]string{name}, flags...)...)
+}
+
+func (r *ReplayHelm) Fetch(chart string, flags ...string) error {
+	_, err := r.next("Fetch", append([]string{chart}, flags...)...)
+	return err
+}
+
+func (r *ReplayHelm) ChartPull(chart string, path string, flags ...string) error {
+	_, err := r.next("ChartPull", append([]string{chart, path}, flags...)...)
+	return err
+}
+
+func (r *ReplayHelm) ChartExport(chart string, path string) error {
+	_, err := r.next("ChartExport", chart, path)
+	return err
+}
+
+// ShowChart encodes the recorded chart.Metadata as JSON in Call.Stdout, since
+// unlike the other methods here it doesn't return a plain string.
+func (r *ReplayHelm) ShowChart(chartPath string) (chart.Metadata, error) {
+	stdout, err := r.next("ShowChart", chartPath)
+	if err != nil {
+		return chart.Metadata{}, err
+	}
+
+	var meta chart.Metadata
+	if stdout != "" {
+		if jsonErr := json.Unmarshal([]byte(stdout), &meta); jsonErr != nil {
+			return chart.Metadata{}, errors.Wrapf(jsonErr, "decoding ShowChart fixture stdout")
+		}
+	}
+	return meta, nil
+}
+
+func (r *ReplayHelm) AddRepo(name, repository, cafile, certfile, keyfile, username, password string, managed string, passCredentials, skipTLSVerify bool) error {
+	_, err := r.next("AddRepo", name, repository, cafile, certfile, keyfile, username, password, managed,
+		fmt.Sprintf("%v", passCredentials), fmt.Sprintf("%v", skipTLSVerify))
+	return err
+}
+
+func (r *ReplayHelm) UpdateRepo() error {
+	_, err := r.next("UpdateRepo")
+	return err
+}
+
+func (r *ReplayHelm) RegistryLogin(name, username, password, caFile, certFile, keyFile string, skipTLSVerify bool) error {
+	_, err := r.next("RegistryLogin", name, username, password, caFile, certFile, keyFile, fmt.Sprintf("%v", skipTLSVerify))
+	return err
+}
@@ -0,0 +1,56 @@
+package exectest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/helmfile/helmfile/pkg/helmexec"
+)
+
+func TestFixtureRoundTrip(t *testing.T) {
+	fixture := &Fixture{
+		Calls: []Call{
+			{Method: "SyncRelease", Args: []string{"myrelease", "mychart"}, Stdout: "synced"},
+			{Method: "DiffRelease", Args: []string{"myrelease", "mychart", "false"}, Stdout: "diffed"},
+			{Method: "SyncRelease", Args: []string{"otherrelease", "otherchart"}, Error: "boom"},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	if err := SaveFixture(path, fixture); err != nil {
+		t.Fatalf("SaveFixture: %v", err)
+	}
+
+	loaded, err := LoadFixture(path)
+	if err != nil {
+		t.Fatalf("LoadFixture: %v", err)
+	}
+
+	replay := NewReplayHelm(loaded)
+
+	// Replayed in the recorded order: SyncRelease, DiffRelease, then a second
+	// SyncRelease with other args whose recorded error must come back.
+	if err := replay.SyncRelease(helmexec.HelmContext{}, "myrelease", "mychart"); err != nil {
+		t.Fatalf("unexpected error replaying first SyncRelease: %v", err)
+	}
+	if err := replay.DiffRelease(helmexec.HelmContext{}, "myrelease", "mychart", false); err != nil {
+		t.Fatalf("unexpected error replaying DiffRelease: %v", err)
+	}
+	if err := replay.SyncRelease(helmexec.HelmContext{}, "otherrelease", "otherchart"); err == nil || err.Error() != "boom" {
+		t.Fatalf("expected recorded error %q, got %v", "boom", err)
+	}
+}
+
+func TestFixtureRoundTrip_UnrecordedCallFails(t *testing.T) {
+	fixture := &Fixture{
+		Calls: []Call{
+			{Method: "SyncRelease", Args: []string{"myrelease", "mychart"}},
+		},
+	}
+	replay := NewReplayHelm(fixture)
+
+	// Same method, different args than anything recorded: must not match.
+	if err := replay.SyncRelease(helmexec.HelmContext{}, "someone-else", "some-other-chart"); err == nil {
+		t.Fatal("expected an error for a call that doesn't match any recorded entry")
+	}
+}
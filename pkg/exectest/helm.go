@@ -1,12 +1,12 @@
 package exectest
 
 import (
-	"errors"
 	"fmt"
 	"strings"
 	"sync"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/pkg/errors"
 	"helm.sh/helm/v3/pkg/chart"
 
 	"github.com/helmfile/helmfile/pkg/helmexec"
@@ -41,6 +41,8 @@ type Helm struct {
 	FailOnUnexpectedList bool
 	Version              *semver.Version
 
+	ShownCharts map[string]int
+
 	UpdateDepsCallbacks map[string]func(string) error
 
 	DiffMutex     *sync.Mutex
@@ -48,6 +50,9 @@ type Helm struct {
 	ReleasesMutex *sync.Mutex
 
 	Helm3 bool
+
+	showChartMu sync.Mutex
+	chartCache  map[string]chart.Metadata
 }
 
 type Release struct {
@@ -63,7 +68,7 @@ type Affected struct {
 
 func (helm *Helm) UpdateDeps(chart string) error {
 	if strings.Contains(chart, "error") {
-		return fmt.Errorf("simulated UpdateDeps failure for chart: %s", chart)
+		return errors.Errorf("simulated UpdateDeps failure for chart: %s", chart)
 	}
 	helm.Charts = append(helm.Charts, chart)
 
@@ -133,7 +138,7 @@ func (helm *Helm) DiffRelease(context helmexec.HelmContext, name, chart string,
 	key := DiffKey{Name: name, Chart: chart, Flags: strings.Join(flags, "")}
 	err, ok := helm.Diffs[key]
 	if !ok && helm.FailOnUnexpectedDiff {
-		return fmt.Errorf("unexpected diff with key: %v", key)
+		return errors.Errorf("unexpected diff with key: %v", key)
 	}
 	return err
 }
@@ -164,7 +169,7 @@ func (helm *Helm) List(context helmexec.HelmContext, filter string, flags ...str
 		for k := range helm.Lists {
 			keys = append(keys, k.String())
 		}
-		return "", fmt.Errorf("unexpected list key: %v not found in %v", key, strings.Join(keys, ", "))
+		return "", errors.Errorf("unexpected list key: %v not found in %v", key, strings.Join(keys, ", "))
 	}
 	return res, nil
 }
@@ -234,10 +239,29 @@ func (helm *Helm) sync(m *sync.Mutex, f func()) {
 	f()
 }
 
+// ShowChart caches by chartPath alone; it cannot express the real chart+version+repo
+// cache key since the fake's signature carries neither. Failed lookups are never cached.
 func (helm *Helm) ShowChart(chartPath string) (chart.Metadata, error) {
+	helm.showChartMu.Lock()
+	defer helm.showChartMu.Unlock()
+
+	if cached, ok := helm.chartCache[chartPath]; ok {
+		return cached, nil
+	}
+
+	if helm.ShownCharts == nil {
+		helm.ShownCharts = map[string]int{}
+	}
+	helm.ShownCharts[chartPath]++
+
 	switch chartPath {
 	case "../../foo-bar":
-		return chart.Metadata{Version: "3.2.0"}, nil
+		meta := chart.Metadata{Version: "3.2.0"}
+		if helm.chartCache == nil {
+			helm.chartCache = map[string]chart.Metadata{}
+		}
+		helm.chartCache[chartPath] = meta
+		return meta, nil
 	default:
 		return chart.Metadata{}, errors.New("fake test error")
 	}
@@ -0,0 +1,33 @@
+package exectest
+
+import "testing"
+
+func TestShowChart_CachesRepeatedLookups(t *testing.T) {
+	helm := &Helm{}
+
+	if _, err := helm.ShowChart("../../foo-bar"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := helm.ShowChart("../../foo-bar"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := helm.ShownCharts["../../foo-bar"]; got != 1 {
+		t.Errorf("ShownCharts[../../foo-bar] = %d, want 1", got)
+	}
+}
+
+func TestShowChart_FailuresAreNotCached(t *testing.T) {
+	helm := &Helm{}
+
+	if _, err := helm.ShowChart("missing"); err == nil {
+		t.Fatal("expected an error for an unknown chart path")
+	}
+	if _, err := helm.ShowChart("missing"); err == nil {
+		t.Fatal("expected an error for an unknown chart path")
+	}
+
+	if got := helm.ShownCharts["missing"]; got != 2 {
+		t.Errorf("ShownCharts[missing] = %d, want 2 (a failed lookup must not be cached)", got)
+	}
+}